@@ -0,0 +1,184 @@
+package sfo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ensureIndex (re)builds the label index if it hasn't been built yet.
+func (parser *SFOParser) ensureIndex() {
+	if parser.index == nil {
+		parser.rebuildIndex()
+	}
+}
+
+// rebuildIndex recomputes the label index from the current Pairs.
+func (parser *SFOParser) rebuildIndex() {
+	parser.index = make(map[string]int, len(parser.Pairs))
+	for i, pair := range parser.Pairs {
+		parser.index[pair.Label] = i
+	}
+}
+
+// GetString returns the value of a StringType key, with its trailing
+// NUL terminator stripped.
+func (parser *SFOParser) GetString(key string) (string, bool) {
+	parser.ensureIndex()
+	i, ok := parser.index[key]
+	if !ok {
+		return "", false
+	}
+	s, ok := parser.Pairs[i].Value.(string)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSuffix(s, "\x00"), true
+}
+
+// GetInt returns the value of an IntType key.
+func (parser *SFOParser) GetInt(key string) (int32, bool) {
+	parser.ensureIndex()
+	i, ok := parser.index[key]
+	if !ok {
+		return 0, false
+	}
+	v, ok := parser.Pairs[i].Value.(int32)
+	return v, ok
+}
+
+// GetBytes returns the value of a ByteType key.
+func (parser *SFOParser) GetBytes(key string) ([]byte, bool) {
+	parser.ensureIndex()
+	i, ok := parser.index[key]
+	if !ok {
+		return nil, false
+	}
+	b, ok := parser.Pairs[i].Value.([]byte)
+	return b, ok
+}
+
+// setDatafieldUsed keeps PsfSec[i] and Pairs[i].PsfSec in sync, since
+// both carry their own copy of the section header.
+func (parser *SFOParser) setDatafieldUsed(i int, used int32) {
+	parser.PsfSec[i].DatafieldUsed = used
+	parser.Pairs[i].PsfSec.DatafieldUsed = used
+}
+
+// SetString sets the value of an existing StringType key, preserving
+// its current DatafieldSize slot width. It fails if the key does not
+// exist, is not a StringType, or val (plus its NUL terminator) would
+// not fit in the existing slot.
+func (parser *SFOParser) SetString(key, val string) error {
+	parser.ensureIndex()
+	i, ok := parser.index[key]
+	if !ok {
+		return fmt.Errorf("sfo: key %q not found", key)
+	}
+	if parser.Pairs[i].Type != StringType {
+		return fmt.Errorf("sfo: key %q is not a string", key)
+	}
+	used := int32(len(val) + 1)
+	if used > parser.PsfSec[i].DatafieldSize {
+		return fmt.Errorf("sfo: value for key %q (%d bytes incl. NUL) exceeds existing slot size %d", key, used, parser.PsfSec[i].DatafieldSize)
+	}
+	parser.Pairs[i].Value = val + "\x00"
+	parser.setDatafieldUsed(i, used)
+	return nil
+}
+
+// SetInt sets the value of an existing IntType key.
+func (parser *SFOParser) SetInt(key string, val int32) error {
+	parser.ensureIndex()
+	i, ok := parser.index[key]
+	if !ok {
+		return fmt.Errorf("sfo: key %q not found", key)
+	}
+	if parser.Pairs[i].Type != IntType {
+		return fmt.Errorf("sfo: key %q is not an int", key)
+	}
+	parser.Pairs[i].Value = val
+	return nil
+}
+
+// SetBytes sets the value of an existing ByteType key, preserving its
+// current DatafieldSize slot width. It fails if the key does not exist,
+// is not a ByteType, or val would not fit in the existing slot.
+func (parser *SFOParser) SetBytes(key string, val []byte) error {
+	parser.ensureIndex()
+	i, ok := parser.index[key]
+	if !ok {
+		return fmt.Errorf("sfo: key %q not found", key)
+	}
+	if parser.Pairs[i].Type != ByteType {
+		return fmt.Errorf("sfo: key %q is not bytes", key)
+	}
+	used := int32(len(val))
+	if used > parser.PsfSec[i].DatafieldSize {
+		return fmt.Errorf("sfo: value for key %q (%d bytes) exceeds existing slot size %d", key, used, parser.PsfSec[i].DatafieldSize)
+	}
+	cp := make([]byte, len(val))
+	copy(cp, val)
+	parser.Pairs[i].Value = cp
+	parser.setDatafieldUsed(i, used)
+	return nil
+}
+
+// AddPair appends a new key, growing Pairs/PsfSec and incrementing
+// NSects. val must be a string, int32, or []byte, and label must not
+// already exist. The new entry's DatafieldSize is sized to exactly fit
+// val (4-byte aligned); SetString and SetBytes keep that slot width, so
+// a later value set through them must fit within it.
+func (parser *SFOParser) AddPair(label string, val interface{}) error {
+	parser.ensureIndex()
+	if _, exists := parser.index[label]; exists {
+		return fmt.Errorf("sfo: key %q already exists", label)
+	}
+
+	pair := SFOPair{Label: label}
+	switch v := val.(type) {
+	case string:
+		if len(v) == 0 || v[len(v)-1] != 0 {
+			v += "\x00"
+		}
+		pair.Type = StringType
+		pair.Value = v
+		pair.PsfSec.DatafieldUsed = int32(len(v))
+		pair.PsfSec.DatafieldSize = int32(alignment(len(v), 4))
+	case int32:
+		pair.Type = IntType
+		pair.Value = v
+		pair.PsfSec.DatafieldUsed = 4
+		pair.PsfSec.DatafieldSize = 4
+	case []byte:
+		cp := make([]byte, len(v))
+		copy(cp, v)
+		pair.Type = ByteType
+		pair.Value = cp
+		pair.PsfSec.DatafieldUsed = int32(len(v))
+		pair.PsfSec.DatafieldSize = int32(alignment(len(v), 4))
+	default:
+		return fmt.Errorf("sfo: unsupported value type %T for key %q", val, label)
+	}
+
+	parser.index[label] = len(parser.Pairs)
+	parser.Pairs = append(parser.Pairs, pair)
+	parser.PsfSec = append(parser.PsfSec, pair.PsfSec)
+	parser.PsfHdr.NSects = int32(len(parser.Pairs))
+	return nil
+}
+
+// DeletePair removes an existing key, shifting later entries down and
+// decrementing NSects.
+func (parser *SFOParser) DeletePair(label string) error {
+	parser.ensureIndex()
+	i, ok := parser.index[label]
+	if !ok {
+		return fmt.Errorf("sfo: key %q not found", label)
+	}
+
+	parser.Pairs = append(parser.Pairs[:i], parser.Pairs[i+1:]...)
+	parser.PsfSec = append(parser.PsfSec[:i], parser.PsfSec[i+1:]...)
+	parser.PsfHdr.NSects = int32(len(parser.Pairs))
+	parser.rebuildIndex()
+	return nil
+}
@@ -0,0 +1,162 @@
+package sfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// sfoVersion is the PSF version written for SFO files built from scratch
+// (1.01, the version used by retail PS3/PS Vita PARAM.SFO files).
+var sfoVersion = [4]byte{0x01, 0x01, 0x00, 0x00}
+
+// builderEntry holds a pending key/value pair along with the slot width it
+// should occupy in the serialized data block.
+type builderEntry struct {
+	label string
+	typ   SFOType
+	value interface{}
+	used  int32
+	size  int32
+}
+
+// SFOBuilder constructs a PARAM.SFO from scratch, without requiring an
+// existing file to parse and modify. Use NewEmptySFO to create one, add
+// entries with AddString/AddInt/AddBytes, then serialize with WriteTo.
+type SFOBuilder struct {
+	entries map[string]*builderEntry
+}
+
+// NewEmptySFO returns an SFOBuilder with no entries.
+func NewEmptySFO() *SFOBuilder {
+	return &SFOBuilder{entries: make(map[string]*builderEntry)}
+}
+
+// AddString adds or replaces a string-typed entry. maxLen is the fixed
+// DatafieldSize slot width (including the trailing NUL) that real SFO
+// tools expect for keys like TITLE or TITLE_ID; it must be large enough
+// to hold val plus its terminating NUL.
+func (b *SFOBuilder) AddString(key, val string, maxLen int) error {
+	used := len(val) + 1
+	if maxLen <= 0 || used > maxLen {
+		return fmt.Errorf("sfo: value for key %q (%d bytes incl. NUL) exceeds maxLen %d", key, used, maxLen)
+	}
+	b.entries[key] = &builderEntry{
+		label: key,
+		typ:   StringType,
+		value: val,
+		used:  int32(used),
+		size:  int32(maxLen),
+	}
+	return nil
+}
+
+// AddInt adds or replaces an int32-typed entry.
+func (b *SFOBuilder) AddInt(key string, v int32) {
+	b.entries[key] = &builderEntry{
+		label: key,
+		typ:   IntType,
+		value: v,
+		used:  4,
+		size:  4,
+	}
+}
+
+// AddBytes adds or replaces a raw byte-typed entry. maxLen is the fixed
+// DatafieldSize slot width; it must be at least len(v).
+func (b *SFOBuilder) AddBytes(key string, v []byte, maxLen int) error {
+	if maxLen <= 0 || len(v) > maxLen {
+		return fmt.Errorf("sfo: value for key %q (%d bytes) exceeds maxLen %d", key, len(v), maxLen)
+	}
+	cp := make([]byte, len(v))
+	copy(cp, v)
+	b.entries[key] = &builderEntry{
+		label: key,
+		typ:   ByteType,
+		value: cp,
+		used:  int32(len(v)),
+		size:  int32(maxLen),
+	}
+	return nil
+}
+
+// sortedKeys returns the builder's keys sorted alphabetically, matching
+// the key order used by real PS3/PS Vita PARAM.SFO files.
+func (b *SFOBuilder) sortedKeys() []string {
+	keys := make([]string, 0, len(b.entries))
+	for k := range b.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteTo serializes the builder's entries into a complete PARAM.SFO and
+// writes them to w. Keys are written alphabetically, the label table is
+// padded to a multiple of 4 bytes, every data field starts on a 4-byte
+// boundary, and DatafieldSize preserves the slot width
+// passed to AddString/AddBytes (AddInt is always 4 bytes).
+func (b *SFOBuilder) WriteTo(w io.Writer) (int64, error) {
+	keys := b.sortedKeys()
+	nsects := len(keys)
+
+	hdr := PsfHdr{
+		Psf:     [4]byte{0, 'P', 'S', 'F'},
+		Unknown: sfoVersion,
+		NSects:  int32(nsects),
+	}
+
+	secs := make([]PsfSec, nsects)
+
+	var labelBuf, dataBuf []byte
+	for i, key := range keys {
+		entry := b.entries[key]
+
+		secs[i].LabelOff = int16(len(labelBuf))
+		labelBuf = append(labelBuf, key...)
+		labelBuf = append(labelBuf, 0)
+
+		secs[i].DataType = byte(entry.typ)
+		secs[i].DatafieldUsed = entry.used
+		secs[i].DatafieldSize = entry.size
+		secs[i].DataOff = int32(len(dataBuf))
+
+		switch entry.typ {
+		case ByteType:
+			dataBuf = append(dataBuf, entry.value.([]byte)...)
+		case StringType:
+			dataBuf = append(dataBuf, entry.value.(string)...)
+			dataBuf = append(dataBuf, 0)
+		case IntType:
+			var tmp [4]byte
+			binary.LittleEndian.PutUint32(tmp[:], uint32(entry.value.(int32)))
+			dataBuf = append(dataBuf, tmp[:]...)
+		}
+		for len(dataBuf) < int(secs[i].DataOff+secs[i].DatafieldSize) || len(dataBuf)%4 != 0 {
+			dataBuf = append(dataBuf, 0)
+		}
+	}
+
+	hdr.LabelPtr = int32(binary.Size(hdr) + nsects*binary.Size(PsfSec{}))
+	hdr.DataPtr = hdr.LabelPtr + int32(alignment(len(labelBuf), 4))
+	for len(labelBuf) < int(hdr.DataPtr-hdr.LabelPtr) {
+		labelBuf = append(labelBuf, 0)
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, hdr); err != nil {
+		return 0, err
+	}
+	for _, sec := range secs {
+		if err := binary.Write(&buf, binary.LittleEndian, sec); err != nil {
+			return 0, err
+		}
+	}
+	buf.Write(labelBuf)
+	buf.Write(dataBuf)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
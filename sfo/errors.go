@@ -0,0 +1,20 @@
+package sfo
+
+import "fmt"
+
+// SFOError reports a problem found while parsing or validating an SFO
+// file, along with the byte offset and field it was found at so callers
+// can distinguish "not an SFO" from "truncated partway through parsing".
+type SFOError struct {
+	Offset     int64
+	Field      string
+	Underlying error
+}
+
+func (e *SFOError) Error() string {
+	return fmt.Sprintf("sfo: %s at offset %d: %v", e.Field, e.Offset, e.Underlying)
+}
+
+func (e *SFOError) Unwrap() error {
+	return e.Underlying
+}
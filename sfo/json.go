@@ -0,0 +1,154 @@
+package sfo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// jsonField is the on-disk JSON representation of a single SFO key,
+// preserving the type and slot width that would otherwise be lost by
+// dumping bare key/value pairs.
+type jsonField struct {
+	Type  string      `json:"type"`
+	Max   int32       `json:"max,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+func typeName(t SFOType) (string, error) {
+	switch t {
+	case StringType:
+		return "string", nil
+	case IntType:
+		return "int", nil
+	case ByteType:
+		return "bytes", nil
+	default:
+		return "", fmt.Errorf("sfo: unknown SFOType %d", t)
+	}
+}
+
+// MarshalJSON dumps the parser's contents to the schema
+// {"KEY": {"type": "string"|"int"|"bytes", "max": N, "value": ...}, ...},
+// preserving the StringType/IntType/ByteType distinction and the fixed
+// slot width ("max") so that UnmarshalJSON can round-trip without loss.
+func (parser *SFOParser) MarshalJSON() ([]byte, error) {
+	out := make(map[string]jsonField, len(parser.Pairs))
+	for _, pair := range parser.Pairs {
+		typ, err := typeName(pair.Type)
+		if err != nil {
+			return nil, fmt.Errorf("sfo: key %q: %w", pair.Label, err)
+		}
+		field := jsonField{Type: typ, Value: pair.Value}
+		if pair.Type == StringType {
+			// Pairs carry the trailing NUL internally; hide it from the
+			// human-editable JSON form.
+			field.Value = strings.TrimSuffix(pair.Value.(string), "\x00")
+		}
+		if pair.Type == StringType || pair.Type == ByteType {
+			field.Max = pair.PsfSec.DatafieldSize
+		}
+		out[pair.Label] = field
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON rebuilds the parser's Pairs (and a matching PsfSec slot
+// width for each) from JSON produced by MarshalJSON. Keys are sorted
+// alphabetically, matching the order real PARAM.SFO files use.
+func (parser *SFOParser) UnmarshalJSON(data []byte) error {
+	var raw map[string]jsonField
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parser.PsfHdr = PsfHdr{
+		Psf:     [4]byte{0, 'P', 'S', 'F'},
+		Unknown: sfoVersion,
+		NSects:  int32(len(keys)),
+	}
+	parser.Pairs = make([]SFOPair, len(keys))
+
+	for i, key := range keys {
+		field := raw[key]
+		pair := SFOPair{Label: key}
+
+		switch field.Type {
+		case "string":
+			val, ok := field.Value.(string)
+			if !ok {
+				return fmt.Errorf("sfo: key %q: expected string value", key)
+			}
+			maxLen := int(field.Max)
+			if maxLen == 0 {
+				maxLen = len(val) + 1
+			}
+			pair.Type = StringType
+			pair.Value = val + "\x00"
+			pair.PsfSec.DatafieldUsed = int32(len(val) + 1)
+			pair.PsfSec.DatafieldSize = int32(maxLen)
+		case "int":
+			num, ok := field.Value.(float64)
+			if !ok {
+				return fmt.Errorf("sfo: key %q: expected numeric value", key)
+			}
+			pair.Type = IntType
+			pair.Value = int32(num)
+			pair.PsfSec.DatafieldUsed = 4
+			pair.PsfSec.DatafieldSize = 4
+		case "bytes":
+			str, ok := field.Value.(string)
+			if !ok {
+				return fmt.Errorf("sfo: key %q: expected base64 string value", key)
+			}
+			val, err := base64.StdEncoding.DecodeString(str)
+			if err != nil {
+				return fmt.Errorf("sfo: key %q: invalid base64: %w", key, err)
+			}
+			maxLen := int(field.Max)
+			if maxLen == 0 {
+				maxLen = len(val)
+			}
+			pair.Type = ByteType
+			pair.Value = val
+			pair.PsfSec.DatafieldUsed = int32(len(val))
+			pair.PsfSec.DatafieldSize = int32(maxLen)
+		default:
+			return fmt.Errorf("sfo: key %q: unknown type %q", key, field.Type)
+		}
+
+		parser.Pairs[i] = pair
+	}
+
+	parser.PsfSec = make([]PsfSec, len(parser.Pairs))
+	for i, pair := range parser.Pairs {
+		parser.PsfSec[i] = pair.PsfSec
+	}
+	parser.rebuildIndex()
+
+	return nil
+}
+
+// FromJSON builds an SFOParser from JSON produced by MarshalJSON (or
+// written by hand following the same schema), so a PARAM.SFO can be
+// edited as a text file and reassembled.
+func FromJSON(r io.Reader) (*SFOParser, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	parser := &SFOParser{}
+	if err := parser.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
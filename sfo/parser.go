@@ -5,10 +5,17 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
+	"path/filepath"
 )
 
+// maxLabelLen bounds how many bytes readByteString will read before
+// giving up, so a malformed file with no NUL terminator can't make the
+// parser read megabytes of garbage looking for one.
+const maxLabelLen = 1024
+
 type SFOType byte
 
 const (
@@ -46,6 +53,12 @@ type SFOParser struct {
 	PsfSec   []PsfSec
 	Pairs    []SFOPair
 	FilePath string
+
+	// index maps a label to its position in Pairs/PsfSec. It is built
+	// when a file or JSON document is loaded, and rebuilt lazily by
+	// ensureIndex whenever it is nil, so GetValue and the typed accessors
+	// are O(1).
+	index map[string]int
 }
 
 func alignment(num, align int) int {
@@ -56,7 +69,9 @@ func alignment(num, align int) int {
 	return num
 }
 
-func readByteString(r io.Reader) ([]byte, error) {
+// readByteString reads bytes up to a NUL terminator, failing once more
+// than maxLen bytes have been read without finding one.
+func readByteString(r io.Reader, maxLen int) ([]byte, error) {
 	var result []byte
 	buf := make([]byte, 1)
 	for {
@@ -68,10 +83,14 @@ func readByteString(r io.Reader) ([]byte, error) {
 			break
 		}
 		result = append(result, buf[0])
+		if len(result) > maxLen {
+			return nil, fmt.Errorf("label exceeds %d bytes without a NUL terminator", maxLen)
+		}
 	}
 	return result, nil
 }
 
+// NewSFOParser reads and parses the PARAM.SFO file at filePath.
 func NewSFOParser(filePath string) (*SFOParser, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -87,117 +106,300 @@ func NewSFOParser(filePath string) (*SFOParser, error) {
 	}
 	defer file.Close()
 
-	parser := &SFOParser{FilePath: filePath}
-	err = binary.Read(file, binary.LittleEndian, &parser.PsfHdr)
+	parser, err := NewSFOParserFromReader(file, fileInfo.Size())
 	if err != nil {
 		return nil, err
 	}
+	parser.FilePath = filePath
+	return parser, nil
+}
+
+// NewSFOParserFromReader parses a PARAM.SFO read from r, which spans size
+// bytes. This allows decoding SFO blobs embedded in larger containers
+// (PKG/PUP archives, ISO images, HTTP responses) without writing them to
+// a temporary file first.
+func NewSFOParserFromReader(r io.ReaderAt, size int64) (*SFOParser, error) {
+	return parseFrom(io.NewSectionReader(r, 0, size))
+}
+
+// NewSFOParserFromBytes parses a PARAM.SFO held entirely in memory.
+func NewSFOParserFromBytes(b []byte) (*SFOParser, error) {
+	return NewSFOParserFromReader(bytes.NewReader(b), int64(len(b)))
+}
+
+// sizedReadSeeker is the subset of *io.SectionReader that parseFrom needs
+// to bounds-check offsets against the underlying file size.
+type sizedReadSeeker interface {
+	io.ReadSeeker
+	Size() int64
+}
+
+func parseFrom(r sizedReadSeeker) (*SFOParser, error) {
+	size := r.Size()
+
+	parser := &SFOParser{}
+	if err := binary.Read(r, binary.LittleEndian, &parser.PsfHdr); err != nil {
+		return nil, &SFOError{Offset: 0, Field: "header", Underlying: err}
+	}
 
 	if !bytes.Equal(parser.PsfHdr.Psf[:], []byte{0, 'P', 'S', 'F'}) {
-		return nil, errors.New("not a valid SFO file")
+		return nil, &SFOError{Offset: 0, Field: "magic", Underlying: errors.New("not a valid SFO file")}
 	}
 
-	parser.PsfSec = make([]PsfSec, parser.PsfHdr.NSects)
-	for i := 0; i < int(parser.PsfHdr.NSects); i++ {
-		err = binary.Read(file, binary.LittleEndian, &parser.PsfSec[i])
-		if err != nil {
-			return nil, err
+	hdrSize := int64(binary.Size(parser.PsfHdr))
+	secSize := int64(binary.Size(PsfSec{}))
+	nsects := int64(parser.PsfHdr.NSects)
+	if nsects < 0 || hdrSize+nsects*secSize > size {
+		return nil, &SFOError{
+			Offset:     hdrSize,
+			Field:      "NSects",
+			Underlying: fmt.Errorf("%d sections would exceed file size %d", nsects, size),
 		}
 	}
 
-	parser.Pairs = make([]SFOPair, parser.PsfHdr.NSects)
-	for i := 0; i < int(parser.PsfHdr.NSects); i++ {
-		file.Seek(int64(parser.PsfSec[i].LabelOff+int16(parser.PsfHdr.LabelPtr)), io.SeekStart)
-		tmpbuffer, err := readByteString(file)
+	parser.PsfSec = make([]PsfSec, nsects)
+	for i := int64(0); i < nsects; i++ {
+		if err := binary.Read(r, binary.LittleEndian, &parser.PsfSec[i]); err != nil {
+			return nil, &SFOError{Offset: hdrSize + i*secSize, Field: fmt.Sprintf("section %d header", i), Underlying: err}
+		}
+	}
+
+	parser.Pairs = make([]SFOPair, nsects)
+	for i := int64(0); i < nsects; i++ {
+		sec := parser.PsfSec[i]
+
+		labelOff := int64(sec.LabelOff) + int64(parser.PsfHdr.LabelPtr)
+		if labelOff < 0 || labelOff >= size {
+			return nil, &SFOError{Offset: labelOff, Field: fmt.Sprintf("section %d LabelOff", i), Underlying: errors.New("offset out of bounds")}
+		}
+
+		dataOff := int64(sec.DataOff) + int64(parser.PsfHdr.DataPtr)
+		dataEnd := dataOff + int64(sec.DatafieldUsed)
+		if dataOff < 0 || sec.DatafieldUsed < 0 || dataEnd > size {
+			return nil, &SFOError{Offset: dataOff, Field: fmt.Sprintf("section %d data", i), Underlying: errors.New("data field exceeds file size")}
+		}
+
+		if _, err := r.Seek(labelOff, io.SeekStart); err != nil {
+			return nil, &SFOError{Offset: labelOff, Field: fmt.Sprintf("section %d label", i), Underlying: err}
+		}
+		tmpbuffer, err := readByteString(r, maxLabelLen)
 		if err != nil {
-			return nil, err
+			return nil, &SFOError{Offset: labelOff, Field: fmt.Sprintf("section %d label", i), Underlying: err}
 		}
 		parser.Pairs[i].Label = string(tmpbuffer)
-		parser.Pairs[i].PsfSec = parser.PsfSec[i]
+		parser.Pairs[i].PsfSec = sec
 
-		file.Seek(int64(parser.PsfSec[i].DataOff+parser.PsfHdr.DataPtr), io.SeekStart)
-		tmpbuffer = make([]byte, parser.PsfSec[i].DatafieldUsed)
-		_, err = file.Read(tmpbuffer)
-		if err != nil {
-			return nil, err
+		if _, err := r.Seek(dataOff, io.SeekStart); err != nil {
+			return nil, &SFOError{Offset: dataOff, Field: fmt.Sprintf("section %d data", i), Underlying: err}
+		}
+		tmpbuffer = make([]byte, sec.DatafieldUsed)
+		if _, err := io.ReadFull(r, tmpbuffer); err != nil {
+			return nil, &SFOError{Offset: dataOff, Field: fmt.Sprintf("section %d data", i), Underlying: err}
 		}
-		parser.Pairs[i].Type = SFOType(parser.PsfSec[i].DataType)
-		switch parser.PsfSec[i].DataType {
+		parser.Pairs[i].Type = SFOType(sec.DataType)
+		switch sec.DataType {
 		case 0:
 			parser.Pairs[i].Value = tmpbuffer
 		case 2:
 			parser.Pairs[i].Value = string(tmpbuffer)
 		case 4:
+			if len(tmpbuffer) < 4 {
+				return nil, &SFOError{Offset: dataOff, Field: fmt.Sprintf("section %d data", i), Underlying: errors.New("int field shorter than 4 bytes")}
+			}
 			parser.Pairs[i].Value = int32(binary.LittleEndian.Uint32(tmpbuffer))
 		}
 	}
+	parser.rebuildIndex()
 	return parser, nil
 }
 
-func (parser *SFOParser) SaveSFO() error {
-	file, err := os.OpenFile(parser.FilePath, os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+// Verify checks that the parser's offsets are internally consistent: the
+// magic is "\0PSF", every section's data field is monotonic and does not
+// overlap another section's, and every string value ends in NUL. Parsing
+// already rejects offsets pointing outside the file; Verify catches
+// structurally-valid-but-nonsensical files that parsing alone would miss.
+func (parser *SFOParser) Verify() error {
+	if !bytes.Equal(parser.PsfHdr.Psf[:], []byte{0, 'P', 'S', 'F'}) {
+		return &SFOError{Field: "magic", Underlying: errors.New("not a valid SFO file")}
 	}
-	defer file.Close()
 
-	var buf bytes.Buffer
+	type span struct {
+		start, end int64
+		field      string
+	}
+	spans := make([]span, len(parser.PsfSec))
+	for i, sec := range parser.PsfSec {
+		start := int64(sec.DataOff)
+		end := start + int64(sec.DatafieldSize)
+		if end < start {
+			return &SFOError{Offset: start, Field: fmt.Sprintf("section %d data", i), Underlying: errors.New("negative-length data field")}
+		}
+		spans[i] = span{start, end, fmt.Sprintf("section %d data", i)}
+	}
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start < spans[i-1].start {
+			return &SFOError{Offset: spans[i].start, Field: spans[i].field, Underlying: errors.New("data offset is lower than the preceding section's")}
+		}
+		if spans[i].start < spans[i-1].end {
+			return &SFOError{Offset: spans[i].start, Field: spans[i].field, Underlying: errors.New("overlaps preceding data field")}
+		}
+	}
 
-	parser.PsfHdr.LabelPtr = int32(binary.Size(parser.PsfHdr) + len(parser.PsfSec)*binary.Size(PsfSec{}))
+	for i, pair := range parser.Pairs {
+		if pair.Type != StringType {
+			continue
+		}
+		s, ok := pair.Value.(string)
+		if !ok || len(s) == 0 || s[len(s)-1] != 0 {
+			return &SFOError{Field: fmt.Sprintf("section %d (%s) value", i, pair.Label), Underlying: errors.New("string value does not end in NUL")}
+		}
+	}
+	return nil
+}
+
+// serialize rebuilds the complete PARAM.SFO byte stream from the parser's
+// current PsfHdr/PsfSec/Pairs, updating LabelPtr, DataPtr, LabelOff,
+// DataOff, DataType and DatafieldUsed to match the Pairs. DatafieldSize
+// is preserved (grown only if the value no longer fits) so that a fixed
+// slot width set by the builder, SetString or SetBytes survives a
+// save/reparse round-trip. Syncing DataType here means every pair,
+// regardless of whether it came from parsing, JSON, or AddPair, is
+// written with the correct type byte.
+func (parser *SFOParser) serialize() ([]byte, error) {
+	var labelBuf, dataBuf bytes.Buffer
 
 	// Write label field
-	buf.Reset()
 	for i := 0; i < int(parser.PsfHdr.NSects); i++ {
-		parser.PsfSec[i].LabelOff = int16(buf.Len())
-		buf.WriteString(parser.Pairs[i].Label)
-		buf.WriteByte(0)
+		parser.PsfSec[i].LabelOff = int16(labelBuf.Len())
+		labelBuf.WriteString(parser.Pairs[i].Label)
+		labelBuf.WriteByte(0)
 	}
-	parser.PsfHdr.DataPtr = int32(alignment(buf.Len(), 4))
-
-	for buf.Len() < int(parser.PsfHdr.DataPtr) {
-		buf.WriteByte(0)
+	for labelBuf.Len()%4 != 0 {
+		labelBuf.WriteByte(0)
 	}
 
 	// Write data set
 	for i := 0; i < int(parser.PsfHdr.NSects); i++ {
-		parser.PsfSec[i].DataOff = int32(buf.Len())
+		parser.PsfSec[i].DataOff = int32(dataBuf.Len())
+		parser.PsfSec[i].DataType = byte(parser.Pairs[i].Type)
 		switch parser.Pairs[i].Type {
 		case 0:
-			buf.Write(parser.Pairs[i].Value.([]byte))
+			dataBuf.Write(parser.Pairs[i].Value.([]byte))
 		case 2:
-			buf.WriteString(parser.Pairs[i].Value.(string))
+			dataBuf.WriteString(parser.Pairs[i].Value.(string))
 		case 4:
-			binary.Write(&buf, binary.LittleEndian, parser.Pairs[i].Value.(int32))
+			binary.Write(&dataBuf, binary.LittleEndian, parser.Pairs[i].Value.(int32))
 		}
 
-		parser.PsfSec[i].DatafieldUsed = int32(buf.Len()) - parser.PsfSec[i].DataOff
-		parser.PsfSec[i].DatafieldSize = int32(alignment(int(parser.PsfSec[i].DatafieldUsed), 4))
-		for buf.Len() < int(parser.PsfSec[i].DataOff+parser.PsfSec[i].DatafieldSize) {
-			buf.WriteByte(0)
+		parser.PsfSec[i].DatafieldUsed = int32(dataBuf.Len()) - parser.PsfSec[i].DataOff
+		minSize := int32(alignment(int(parser.PsfSec[i].DatafieldUsed), 4))
+		if parser.PsfSec[i].DatafieldSize < minSize {
+			parser.PsfSec[i].DatafieldSize = minSize
+		}
+		for dataBuf.Len() < int(parser.PsfSec[i].DataOff+parser.PsfSec[i].DatafieldSize) {
+			dataBuf.WriteByte(0)
 		}
 	}
 
-	// Write PsfSec
-	file.Seek(int64(binary.Size(parser.PsfHdr)), io.SeekStart)
+	parser.PsfHdr.LabelPtr = int32(binary.Size(parser.PsfHdr) + len(parser.PsfSec)*binary.Size(PsfSec{}))
+	parser.PsfHdr.DataPtr = parser.PsfHdr.LabelPtr + int32(labelBuf.Len())
+
+	var out bytes.Buffer
+	if err := binary.Write(&out, binary.LittleEndian, parser.PsfHdr); err != nil {
+		return nil, err
+	}
 	for _, sec := range parser.PsfSec {
-		binary.Write(file, binary.LittleEndian, sec)
+		if err := binary.Write(&out, binary.LittleEndian, sec); err != nil {
+			return nil, err
+		}
 	}
-	parser.PsfHdr.LabelPtr = int32(binary.Size(parser.PsfHdr) + len(parser.PsfSec)*binary.Size(PsfSec{}))
+	out.Write(labelBuf.Bytes())
+	out.Write(dataBuf.Bytes())
 
-	// Write PsfHdr
-	file.Seek(0, io.SeekStart)
-	binary.Write(file, binary.LittleEndian, parser.PsfHdr)
+	return out.Bytes(), nil
+}
 
-	return nil
+// WriteTo serializes the parser's current contents as a complete
+// PARAM.SFO and writes them to w, satisfying io.WriterTo. This allows a
+// parsed (or modified) SFO to be piped into archives, network sockets,
+// or an in-memory bytes.Buffer without going through a file on disk.
+func (parser *SFOParser) WriteTo(w io.Writer) (int64, error) {
+	data, err := parser.serialize()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Checksum returns the CRC32 (IEEE) of the parser's current contents as
+// they would be written by SaveSFO, so callers can detect a no-op save
+// before touching disk.
+func (parser *SFOParser) Checksum() uint32 {
+	data, err := parser.serialize()
+	if err != nil {
+		return 0
+	}
+	return crc32.ChecksumIEEE(data)
+}
+
+// SaveSFO writes the parser's current contents back to parser.FilePath.
+func (parser *SFOParser) SaveSFO() error {
+	return parser.SaveSFOTo(parser.FilePath)
+}
+
+// defaultSaveMode is the permission mode a new PARAM.SFO is created
+// with, matching what the old os.OpenFile-based SaveSFO used.
+const defaultSaveMode = 0644
+
+// SaveSFOTo serializes the parser's current contents and atomically
+// writes them to path, without mutating parser.FilePath. The data is
+// written to a temporary file in the same directory and then renamed
+// into place, so a crash mid-write can never leave a truncated or
+// partially-overwritten PARAM.SFO behind, and a shorter new file can
+// never leave trailing garbage from a previous, longer one. The file's
+// existing permissions are preserved, or defaultSaveMode is used for a
+// new file, since os.CreateTemp always creates with mode 0600.
+func (parser *SFOParser) SaveSFOTo(path string) error {
+	data, err := parser.serialize()
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(defaultSaveMode)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".sfo-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
 }
 
 func (parser *SFOParser) GetValue(key string) (interface{}, error) {
-	for _, pair := range parser.Pairs {
-		if pair.Label == key {
-			return pair.Value, nil
-		}
+	parser.ensureIndex()
+	i, ok := parser.index[key]
+	if !ok {
+		return nil, errors.New("key not found")
 	}
-	return nil, errors.New("key not found")
+	return parser.Pairs[i].Value, nil
 }
 
 func (parser *SFOParser) GetLength() int {
@@ -255,5 +457,6 @@ func (parser *SFOParser) SetLabelByIndex(index int, value string) error {
 		return errors.New("index out of range")
 	}
 	parser.Pairs[index].Label = value
+	parser.index = nil // stale after rename; rebuilt lazily on next lookup
 	return nil
 }
@@ -0,0 +1,127 @@
+package sfo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bearts/ps3-sfo-parser/sfo"
+)
+
+func TestSerializePreservesDatafieldSize(t *testing.T) {
+	b := sfo.NewEmptySFO()
+	if err := b.AddString("TITLE", "My Game", 128); err != nil {
+		t.Fatalf("AddString: %v", err)
+	}
+	b.AddInt("PARENTAL_LEVEL", 1)
+
+	var built bytes.Buffer
+	if _, err := b.WriteTo(&built); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	parser, err := sfo.NewSFOParserFromBytes(built.Bytes())
+	if err != nil {
+		t.Fatalf("NewSFOParserFromBytes: %v", err)
+	}
+
+	if err := parser.SetString("TITLE", "X"); err != nil {
+		t.Fatalf("SetString: %v", err)
+	}
+
+	var saved bytes.Buffer
+	if _, err := parser.WriteTo(&saved); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	reparsed, err := sfo.NewSFOParserFromBytes(saved.Bytes())
+	if err != nil {
+		t.Fatalf("NewSFOParserFromBytes (reparsed): %v", err)
+	}
+
+	idx := -1
+	for i, pair := range reparsed.Pairs {
+		if pair.Label == "TITLE" {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		t.Fatal("TITLE not found after round-trip")
+	}
+	if got := reparsed.PsfSec[idx].DatafieldSize; got != 128 {
+		t.Errorf("DatafieldSize after save/reparse = %d, want 128 (fixed slot width must survive)", got)
+	}
+
+	got, ok := reparsed.GetString("TITLE")
+	if !ok || got != "X" {
+		t.Errorf("GetString(TITLE) = %q, %v; want %q, true", got, ok, "X")
+	}
+}
+
+func TestJSONRoundTripPreservesType(t *testing.T) {
+	src := `{"TITLE":{"type":"string","max":128,"value":"My Game"},"PARENTAL_LEVEL":{"type":"int","value":1}}`
+
+	parser, err := sfo.FromJSON(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+
+	var saved bytes.Buffer
+	if _, err := parser.WriteTo(&saved); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	reparsed, err := sfo.NewSFOParserFromBytes(saved.Bytes())
+	if err != nil {
+		t.Fatalf("NewSFOParserFromBytes: %v", err)
+	}
+
+	if got, ok := reparsed.GetString("TITLE"); !ok || got != "My Game" {
+		t.Errorf("GetString(TITLE) = %q, %v; want %q, true", got, ok, "My Game")
+	}
+	if got, ok := reparsed.GetInt("PARENTAL_LEVEL"); !ok || got != 1 {
+		t.Errorf("GetInt(PARENTAL_LEVEL) = %d, %v; want 1, true", got, ok)
+	}
+}
+
+func TestAddPairRoundTripPreservesType(t *testing.T) {
+	parser := &sfo.SFOParser{
+		PsfHdr: sfo.PsfHdr{Psf: [4]byte{0, 'P', 'S', 'F'}},
+	}
+	if err := parser.AddPair("PARENTAL_LEVEL", int32(5)); err != nil {
+		t.Fatalf("AddPair: %v", err)
+	}
+
+	var saved bytes.Buffer
+	if _, err := parser.WriteTo(&saved); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	reparsed, err := sfo.NewSFOParserFromBytes(saved.Bytes())
+	if err != nil {
+		t.Fatalf("NewSFOParserFromBytes: %v", err)
+	}
+
+	if got, ok := reparsed.GetInt("PARENTAL_LEVEL"); !ok || got != 5 {
+		t.Errorf("GetInt(PARENTAL_LEVEL) = %d, %v; want 5, true", got, ok)
+	}
+}
+
+func TestVerifyRejectsOverlappingDataFields(t *testing.T) {
+	parser := &sfo.SFOParser{
+		PsfHdr: sfo.PsfHdr{Psf: [4]byte{0, 'P', 'S', 'F'}, NSects: 2},
+		PsfSec: []sfo.PsfSec{
+			{DataOff: 0, DatafieldSize: 8},
+			{DataOff: 4, DatafieldSize: 8},
+		},
+		Pairs: []sfo.SFOPair{
+			{Label: "A", Type: sfo.IntType, Value: int32(1)},
+			{Label: "B", Type: sfo.IntType, Value: int32(2)},
+		},
+	}
+
+	if err := parser.Verify(); err == nil {
+		t.Fatal("Verify() = nil, want error for overlapping data fields")
+	}
+}